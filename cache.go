@@ -0,0 +1,389 @@
+package tafuta
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the raw bytes of a cached HTTP response, keyed by an
+// opaque string built from the request that produced it. Implementations
+// only need to be a dumb key/value store; CacheTransport does all the
+// RFC 7234 bookkeeping.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// MemoryCache is a Cache backed by a plain map, safe for concurrent use.
+// It does not survive a page reload; use an IndexedDBCache for that.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string][]byte
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string][]byte)}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = value
+}
+
+// Delete implements Cache.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// CacheTransport layers a RFC 7234 ("HTTP Caching") compliant cache on top
+// of another http.RoundTripper, storing entries in Cache. It honors
+// Cache-Control (max-age, no-store, no-cache, must-revalidate, private),
+// Expires, Vary, Age, ETag and Last-Modified. This is independent of, and
+// gives the Go program more control than, the browser's own HTTP cache
+// (see the Cache field of Request/Transport for that) - in particular it
+// keeps working inside a SharedWorker, where the browser cache semantics
+// differ.
+//
+//	client := &http.Client{
+//		Transport: tafuta.NewCacheTransport(tafuta.NewTransport(), tafuta.NewMemoryCache()),
+//	}
+//
+type CacheTransport struct {
+	// Transport performs the actual network round trip on a cache miss
+	// or when revalidating a stale entry. Defaults to a plain Transport
+	// when nil.
+	Transport http.RoundTripper
+
+	// Cache stores the cached responses. Required.
+	Cache Cache
+}
+
+// NewCacheTransport returns a CacheTransport that stores entries in cache
+// and round trips through rt on a miss. rt may be nil to use a plain
+// Transport.
+func NewCacheTransport(rt http.RoundTripper, cache Cache) *CacheTransport {
+	return &CacheTransport{Transport: rt, Cache: cache}
+}
+
+func (c *CacheTransport) transport() http.RoundTripper {
+	return defaultRoundTripper(c.Transport)
+}
+
+// defaultRoundTripper returns rt, falling back to a plain Transport when rt
+// is nil.
+func defaultRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if rt != nil {
+		return rt
+	}
+	return NewTransport()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := cacheKey(req)
+	reqCC := parseCacheControl(req.Header.Get("Cache-Control"))
+	_, noStore := reqCC["no-store"]
+	_, noCache := reqCC["no-cache"]
+
+	entry, hit := loadEntry(c.Cache, key)
+	if hit && !varyMatches(entry, req) {
+		hit = false
+	}
+	if !hit {
+		return c.fetch(key, req, noStore)
+	}
+	if fresh, _, _ := freshness(entry); fresh && !noCache {
+		return entry.toResponse(req, true), nil
+	}
+	return c.revalidate(key, entry, req, noStore)
+}
+
+// fetch performs a plain round trip for a request that missed the cache,
+// storing the response when it is cacheable.
+func (c *CacheTransport) fetch(key string, req *http.Request, noStore bool) (*http.Response, error) {
+	resp, err := c.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if noStore || !cacheable(req, resp) {
+		return resp, nil
+	}
+	return storeEntry(c.Cache, key, req, resp)
+}
+
+// revalidate issues a conditional request for a stale entry. When noStore is
+// set (the request sent Cache-Control: no-store) nothing is written back.
+func (c *CacheTransport) revalidate(key string, entry *cachedEntry, req *http.Request, noStore bool) (*http.Response, error) {
+	cc := parseCacheControl(entry.Header.Get("Cache-Control"))
+	_, mustRevalidate := cc["must-revalidate"]
+
+	creq := req.Clone(req.Context())
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		creq.Header.Set("If-None-Match", etag)
+	}
+	if lm := entry.Header.Get("Last-Modified"); lm != "" {
+		creq.Header.Set("If-Modified-Since", lm)
+	}
+
+	resp, err := c.transport().RoundTrip(creq)
+	if err != nil {
+		if mustRevalidate {
+			return gatewayTimeout(req), nil
+		}
+		// stale-if-error: serve what we have rather than fail the call.
+		return entry.toResponse(req, true), nil
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		for k, v := range resp.Header {
+			entry.Header[k] = v
+		}
+		entry.ResponseTime = time.Now()
+		if !noStore {
+			if err := saveEntry(c.Cache, key, entry); err != nil {
+				return nil, err
+			}
+		}
+		return entry.toResponse(req, true), nil
+	}
+	if noStore {
+		return resp, nil
+	}
+	if !cacheable(req, resp) {
+		c.Cache.Delete(key)
+		return resp, nil
+	}
+	return storeEntry(c.Cache, key, req, resp)
+}
+
+// cachedEntry is the RFC 7234 metadata tafuta needs to keep around for a
+// stored response, serialized as JSON for Cache.Set.
+type cachedEntry struct {
+	RequestHeader http.Header
+	StatusCode    int
+	Status        string
+	Header        http.Header
+	Body          []byte
+	ResponseTime  time.Time
+}
+
+func cacheKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+func loadEntry(cache Cache, key string) (*cachedEntry, bool) {
+	b, ok := cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var e cachedEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
+func saveEntry(cache Cache, key string, e *cachedEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	cache.Set(key, b)
+	return nil
+}
+
+// storeEntry buffers resp's body so it can be cached, then hands the
+// caller back an equivalent response with a fresh, unread body.
+func storeEntry(cache Cache, key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	e := &cachedEntry{
+		RequestHeader: varyHeader(resp.Header, req.Header),
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		Header:        resp.Header.Clone(),
+		Body:          data,
+		ResponseTime:  time.Now(),
+	}
+	if err := saveEntry(cache, key, e); err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+// toResponse rebuilds a *http.Response from a cached entry. fromCache sets
+// the X-From-Cache marker header callers can check for.
+func (e *cachedEntry) toResponse(req *http.Request, fromCache bool) *http.Response {
+	h := e.Header.Clone()
+	if fromCache {
+		h.Set("X-From-Cache", "1")
+	}
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// varyHeader captures the request header values named in resp's Vary
+// header, so a later lookup can tell whether this entry applies.
+func varyHeader(respHeader, reqHeader http.Header) http.Header {
+	out := make(http.Header)
+	for _, name := range strings.Split(respHeader.Get("Vary"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if v := reqHeader.Get(name); v != "" {
+			out.Set(name, v)
+		}
+	}
+	return out
+}
+
+func varyMatches(e *cachedEntry, req *http.Request) bool {
+	vary := e.Header.Get("Vary")
+	if vary == "" {
+		return true
+	}
+	if strings.Contains(vary, "*") {
+		return false
+	}
+	for _, name := range strings.Split(vary, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if e.RequestHeader.Get(name) != req.Header.Get(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheable reports whether resp is allowed to be stored at all.
+func cacheable(req *http.Request, resp *http.Response) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if _, ok := cc["private"]; ok {
+		return false
+	}
+	switch resp.StatusCode {
+	case 200, 203, 204, 206, 300, 301, 404, 405, 410, 414, 501:
+		return true
+	}
+	return false
+}
+
+// freshness implements the RFC 7234 §4.2 freshness lifetime/current age
+// calculation, simplified to the directives tafuta understands.
+func freshness(e *cachedEntry) (fresh bool, lifetime, age time.Duration) {
+	cc := parseCacheControl(e.Header.Get("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return false, 0, 0
+	}
+	date := dateHeader(e.Header, e.ResponseTime)
+	age = currentAge(e, date)
+	if v, ok := cc["max-age"]; ok {
+		if secs, err := strconv.Atoi(v); err == nil {
+			lifetime = time.Duration(secs) * time.Second
+		}
+	} else if exp := e.Header.Get("Expires"); exp != "" {
+		if t, err := http.ParseTime(exp); err == nil {
+			lifetime = t.Sub(date)
+		}
+	}
+	return age < lifetime, lifetime, age
+}
+
+// currentAge implements RFC 7234 §4.2.3.
+func currentAge(e *cachedEntry, date time.Time) time.Duration {
+	apparentAge := e.ResponseTime.Sub(date)
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	var ageValue time.Duration
+	if v := e.Header.Get("Age"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			ageValue = time.Duration(secs) * time.Second
+		}
+	}
+	correctedAge := apparentAge
+	if ageValue > correctedAge {
+		correctedAge = ageValue
+	}
+	return correctedAge + time.Since(e.ResponseTime)
+}
+
+func dateHeader(h http.Header, fallback time.Time) time.Time {
+	if v := h.Get("Date"); v != "" {
+		if t, err := http.ParseTime(v); err == nil {
+			return t
+		}
+	}
+	return fallback
+}
+
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}
+}
+
+func parseCacheControl(v string) map[string]string {
+	out := make(map[string]string)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if i := strings.Index(part, "="); i >= 0 {
+			out[strings.ToLower(part[:i])] = strings.Trim(part[i+1:], `"`)
+		} else {
+			out[strings.ToLower(part)] = ""
+		}
+	}
+	return out
+}
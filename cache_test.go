@@ -0,0 +1,139 @@
+package tafuta
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{"empty", "", map[string]string{}},
+		{"bare directive", "no-store", map[string]string{"no-store": ""}},
+		{"value", "max-age=60", map[string]string{"max-age": "60"}},
+		{"quoted value", `private="x-foo"`, map[string]string{"private": "x-foo"}},
+		{
+			"multiple, mixed spacing",
+			"no-cache,  max-age=0 , must-revalidate",
+			map[string]string{"no-cache": "", "max-age": "0", "must-revalidate": ""},
+		},
+		{"case insensitive directive names", "NO-STORE", map[string]string{"no-store": ""}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := parseCacheControl(c.in)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("parseCacheControl(%q) = %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	get := &http.Request{Method: http.MethodGet}
+	post := &http.Request{Method: http.MethodPost}
+
+	cases := []struct {
+		name string
+		req  *http.Request
+		resp *http.Response
+		want bool
+	}{
+		{"GET 200", get, &http.Response{StatusCode: 200, Header: http.Header{}}, true},
+		{"GET 404", get, &http.Response{StatusCode: 404, Header: http.Header{}}, true},
+		{"GET 500", get, &http.Response{StatusCode: 500, Header: http.Header{}}, false},
+		{"POST is never cacheable", post, &http.Response{StatusCode: 200, Header: http.Header{}}, false},
+		{
+			"no-store response",
+			get,
+			&http.Response{StatusCode: 200, Header: http.Header{"Cache-Control": {"no-store"}}},
+			false,
+		},
+		{
+			"private response",
+			get,
+			&http.Response{StatusCode: 200, Header: http.Header{"Cache-Control": {"private"}}},
+			false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := cacheable(c.req, c.resp); got != c.want {
+				t.Errorf("cacheable() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestVaryMatches(t *testing.T) {
+	entry := &cachedEntry{
+		RequestHeader: http.Header{"Accept-Language": {"en"}},
+		Header:        http.Header{"Vary": {"Accept-Language"}},
+	}
+	match := &http.Request{Header: http.Header{"Accept-Language": {"en"}}}
+	mismatch := &http.Request{Header: http.Header{"Accept-Language": {"fr"}}}
+
+	if !varyMatches(entry, match) {
+		t.Error("varyMatches() = false, want true for identical vary header")
+	}
+	if varyMatches(entry, mismatch) {
+		t.Error("varyMatches() = true, want false for differing vary header")
+	}
+
+	noVary := &cachedEntry{Header: http.Header{}}
+	if !varyMatches(noVary, mismatch) {
+		t.Error("varyMatches() = false, want true when entry has no Vary header")
+	}
+
+	star := &cachedEntry{Header: http.Header{"Vary": {"*"}}}
+	if varyMatches(star, match) {
+		t.Error("varyMatches() = true, want false when Vary is *")
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	now := time.Now()
+
+	fresh := &cachedEntry{
+		Header:       http.Header{"Cache-Control": {"max-age=60"}, "Date": {now.Format(http.TimeFormat)}},
+		ResponseTime: now,
+	}
+	if ok, _, _ := freshness(fresh); !ok {
+		t.Error("freshness() = false, want true for a response within max-age")
+	}
+
+	stale := &cachedEntry{
+		Header:       http.Header{"Cache-Control": {"max-age=60"}, "Date": {now.Add(-2 * time.Minute).Format(http.TimeFormat)}},
+		ResponseTime: now.Add(-2 * time.Minute),
+	}
+	if ok, _, _ := freshness(stale); ok {
+		t.Error("freshness() = true, want false for a response past max-age")
+	}
+
+	noCache := &cachedEntry{
+		Header:       http.Header{"Cache-Control": {"no-cache"}, "Date": {now.Format(http.TimeFormat)}},
+		ResponseTime: now,
+	}
+	if ok, _, _ := freshness(noCache); ok {
+		t.Error("freshness() = true, want false when Cache-Control is no-cache")
+	}
+}
+
+func TestCurrentAge(t *testing.T) {
+	date := time.Now().Add(-10 * time.Second)
+	e := &cachedEntry{
+		Header:       http.Header{"Age": {"5"}},
+		ResponseTime: date.Add(8 * time.Second),
+	}
+	age := currentAge(e, date)
+	// apparent age (8s) beats the reported Age header (5s); current age
+	// adds elapsed time since ResponseTime on top of that.
+	if age < 8*time.Second {
+		t.Errorf("currentAge() = %v, want at least 8s", age)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 
 	"github.com/gernest/tafuta"
 )
@@ -18,5 +19,9 @@ func main() {
 	if err != nil {
 		// handle error
 	}
-	fmt.Println(res.Text())
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		// handle error
+	}
+	fmt.Println(string(body))
 }
@@ -0,0 +1,79 @@
+package tafuta
+
+import (
+	"context"
+	"errors"
+	"syscall/js"
+)
+
+// FormData wraps the browser's FormData object, letting callers build
+// multipart/form-data request bodies - file uploads, form fields, values
+// lifted straight from an <input type=file> element - without hand-building
+// a boundary-delimited io.Reader. Use it as a Request's Body; Client.Do
+// recognizes *FormData and Transport.RoundTrip passes it straight through to
+// fetch so the browser sets the correct Content-Type boundary itself. A
+// Transport only finds it on the request's context (see WithFormData), not
+// as an *http.Request's Body.
+//
+// application/x-www-form-urlencoded bodies don't need a dedicated type:
+// encode them with net/url.Values.Encode and set them as the Body along
+// with a "Content-Type: application/x-www-form-urlencoded" header, the
+// same as any other string/io.Reader body.
+type FormData struct {
+	value js.Value
+}
+
+// NewFormData returns an empty FormData.
+func NewFormData() *FormData {
+	return &FormData{value: js.Global().Get("FormData").New()}
+}
+
+// Append adds a string field named name.
+func (f *FormData) Append(name, value string) {
+	f.value.Call("append", name, value)
+}
+
+// AppendBlob adds data as a file field named name, with the given filename
+// and MIME content type.
+func (f *FormData) AppendBlob(name string, data []byte, filename, contentType string) {
+	a := js.TypedArrayOf(data)
+	defer a.Release()
+	blob := js.Global().Get("Blob").New(
+		js.ValueOf([]interface{}{a.Value}),
+		map[string]interface{}{"type": contentType},
+	)
+	f.value.Call("append", name, blob, filename)
+}
+
+// AppendFile adds file - a File or Blob value, such as one picked up from
+// an <input type=file> element's "files" property - as a field named name.
+func (f *FormData) AppendFile(name string, file js.Value) {
+	f.value.Call("append", name, file)
+}
+
+// Value returns the underlying js FormData value.
+func (f *FormData) Value() js.Value {
+	return f.value
+}
+
+// Read makes *FormData satisfy io.Reader, the type of Request.Body, but it
+// must never actually be invoked: Client.Do special-cases a *FormData body
+// before any reading happens.
+func (f *FormData) Read([]byte) (int, error) {
+	return 0, errors.New("tafuta: FormData must be used as a Request body directly, not read from")
+}
+
+type formDataKey struct{}
+
+// WithFormData returns a copy of ctx carrying f, so that a Transport picks
+// it up as the fetch body in place of req.Body. Client.Do does this for
+// you when Request.Body is a *FormData; use this directly only when
+// building a *http.Request by hand for use with a Transport.
+func WithFormData(ctx context.Context, f *FormData) context.Context {
+	return context.WithValue(ctx, formDataKey{}, f)
+}
+
+func formDataFromContext(ctx context.Context) (*FormData, bool) {
+	f, ok := ctx.Value(formDataKey{}).(*FormData)
+	return f, ok
+}
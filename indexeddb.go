@@ -0,0 +1,116 @@
+package tafuta
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// IndexedDBCache is a Cache backed by the browser's IndexedDB. Unlike
+// MemoryCache it survives page reloads, and it is reachable from a
+// SharedWorker, which has no other persistent storage wasm can get at.
+type IndexedDBCache struct {
+	db    js.Value
+	store string
+}
+
+// NewIndexedDBCache opens (creating if necessary) a database named dbName
+// with an object store named store, and returns a Cache backed by it. This
+// call is blocking.
+func NewIndexedDBCache(dbName, store string) (*IndexedDBCache, error) {
+	req := js.Global().Get("indexedDB").Call("open", dbName, 1)
+	done := make(chan struct{})
+	var db js.Value
+	var openErr error
+
+	upgrade := js.NewCallback(func(v []js.Value) {
+		target := v[0].Get("target").Get("result")
+		if !target.Get("objectStoreNames").Call("contains", store).Bool() {
+			target.Call("createObjectStore", store)
+		}
+	})
+	defer upgrade.Release()
+	success := js.NewCallback(func(v []js.Value) {
+		db = v[0].Get("target").Get("result")
+		done <- struct{}{}
+	})
+	defer success.Release()
+	fail := js.NewCallback(func(v []js.Value) {
+		openErr = fmt.Errorf("tafuta: opening indexeddb %q: %s", dbName,
+			v[0].Get("target").Get("error").Call("toString").String())
+		done <- struct{}{}
+	})
+	defer fail.Release()
+
+	req.Call("addEventListener", "upgradeneeded", upgrade)
+	req.Call("addEventListener", "success", success)
+	req.Call("addEventListener", "error", fail)
+	<-done
+	if openErr != nil {
+		return nil, openErr
+	}
+	return &IndexedDBCache{db: db, store: store}, nil
+}
+
+func (c *IndexedDBCache) transaction(mode string) js.Value {
+	return c.db.Call("transaction", c.store, mode).Call("objectStore", c.store)
+}
+
+// Get implements Cache.
+func (c *IndexedDBCache) Get(key string) (value []byte, found bool) {
+	done := make(chan struct{})
+	req := c.transaction("readonly").Call("get", key)
+	success := js.NewCallback(func(v []js.Value) {
+		result := v[0].Get("target").Get("result")
+		if result.Type() != js.TypeUndefined && result.Type() != js.TypeNull {
+			value = toBytes(result)
+			found = true
+		}
+		done <- struct{}{}
+	})
+	defer success.Release()
+	fail := js.NewCallback(func(v []js.Value) {
+		done <- struct{}{}
+	})
+	defer fail.Release()
+	req.Call("addEventListener", "success", success)
+	req.Call("addEventListener", "error", fail)
+	<-done
+	return
+}
+
+// Set implements Cache.
+func (c *IndexedDBCache) Set(key string, value []byte) {
+	done := make(chan struct{})
+	a := js.TypedArrayOf(value)
+	defer a.Release()
+	req := c.transaction("readwrite").Call("put", a, key)
+	cb := js.NewCallback(func(v []js.Value) {
+		done <- struct{}{}
+	})
+	defer cb.Release()
+	req.Call("addEventListener", "success", cb)
+	req.Call("addEventListener", "error", cb)
+	<-done
+}
+
+// Delete implements Cache.
+func (c *IndexedDBCache) Delete(key string) {
+	done := make(chan struct{})
+	req := c.transaction("readwrite").Call("delete", key)
+	cb := js.NewCallback(func(v []js.Value) {
+		done <- struct{}{}
+	})
+	defer cb.Release()
+	req.Call("addEventListener", "success", cb)
+	req.Call("addEventListener", "error", cb)
+	<-done
+}
+
+// toBytes copies a Uint8Array-backed IndexedDB value into a Go []byte.
+func toBytes(v js.Value) []byte {
+	b := make([]byte, v.Get("length").Int())
+	ta := js.TypedArrayOf(b)
+	ta.Call("set", v)
+	ta.Release()
+	return b
+}
@@ -0,0 +1,98 @@
+package tafuta
+
+import "net/http"
+
+// CacheMode selects how a Client's cache is consulted and populated for a
+// single request, on top of the RFC 7234 semantics CacheTransport already
+// implements. This lets one Client apply different policies to different
+// routes (auth, static data, user-specific data) without constructing
+// separate clients for each.
+type CacheMode uint
+
+const (
+	// StrictMode obeys Cache-Control: no-store/no-cache on either side
+	// and otherwise serves must-revalidate semantics - this is exactly
+	// what CacheTransport already does, and is the default a Matcher
+	// falls back to.
+	StrictMode CacheMode = iota
+
+	// BypassMode never consults or writes the cache: the request always
+	// goes straight to Transport.
+	BypassMode
+
+	// BypassRequestMode ignores any cached entry on read, but still
+	// populates the cache from the response.
+	BypassRequestMode
+
+	// BypassResponseMode reads from the cache as usual, but never stores
+	// a new response.
+	BypassResponseMode
+)
+
+// Matcher picks a CacheMode for req. A nil Matcher is equivalent to one
+// that always returns StrictMode.
+type Matcher func(req *Request) CacheMode
+
+// PolicyCache is a Cache plus a Matcher choosing, per request, which of
+// the CacheMode policies above applies. Assign it to Client.Cache.
+type PolicyCache struct {
+	Cache   Cache
+	Matcher Matcher
+}
+
+// NewPolicyCache returns a PolicyCache storing entries in cache and
+// choosing a mode for each request with match. match may be nil to always
+// use StrictMode.
+func NewPolicyCache(cache Cache, match Matcher) *PolicyCache {
+	return &PolicyCache{Cache: cache, Matcher: match}
+}
+
+func (p *PolicyCache) mode(req *Request) CacheMode {
+	if p.Matcher == nil {
+		return StrictMode
+	}
+	return p.Matcher(req)
+}
+
+// bypassRequestTransport implements BypassRequestMode: always round trips
+// through the network, but still stores a cacheable response.
+type bypassRequestTransport struct {
+	transport http.RoundTripper
+	cache     Cache
+}
+
+// RoundTrip implements http.RoundTripper.
+func (b *bypassRequestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := defaultRoundTripper(b.transport).RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, noStore := parseCacheControl(req.Header.Get("Cache-Control"))["no-store"]; noStore {
+		return resp, nil
+	}
+	if !cacheable(req, resp) {
+		return resp, nil
+	}
+	return storeEntry(b.cache, cacheKey(req), req, resp)
+}
+
+// bypassResponseTransport implements BypassResponseMode: serves a fresh
+// cache entry when there is one, but never stores a new response.
+type bypassResponseTransport struct {
+	transport http.RoundTripper
+	cache     Cache
+}
+
+// RoundTrip implements http.RoundTripper.
+func (b *bypassResponseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, noCache := parseCacheControl(req.Header.Get("Cache-Control"))["no-cache"]
+	if !noCache {
+		key := cacheKey(req)
+		if entry, ok := loadEntry(b.cache, key); ok && varyMatches(entry, req) {
+			if fresh, _, _ := freshness(entry); fresh {
+				return entry.toResponse(req, true), nil
+			}
+		}
+	}
+	return defaultRoundTripper(b.transport).RoundTrip(req)
+}
@@ -0,0 +1,127 @@
+package tafuta
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRoundTripper counts invocations and returns a canned, fresh,
+// cacheable 200 response each time.
+type fakeRoundTripper struct {
+	calls int
+}
+
+func (f *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.calls++
+	return &http.Response{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Header:     http.Header{"Cache-Control": {"max-age=60"}},
+		Body:       ioutil.NopCloser(strings.NewReader("body")),
+		Request:    req,
+	}, nil
+}
+
+// countingCache wraps a MemoryCache and counts Set calls.
+type countingCache struct {
+	*MemoryCache
+	sets int
+}
+
+func (c *countingCache) Set(key string, value []byte) {
+	c.sets++
+	c.MemoryCache.Set(key, value)
+}
+
+func newTestGetRequest() *http.Request {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	return req
+}
+
+func TestBypassRequestTransportAlwaysRefetchesAndStores(t *testing.T) {
+	rt := &fakeRoundTripper{}
+	cache := &countingCache{MemoryCache: NewMemoryCache()}
+	req := newTestGetRequest()
+
+	// Pre-populate a fresh entry, to prove BypassRequestMode ignores it.
+	saveEntry(cache, cacheKey(req), &cachedEntry{
+		StatusCode:   200,
+		Header:       http.Header{"Cache-Control": {"max-age=60"}},
+		ResponseTime: time.Now(),
+	})
+	setsBeforeRoundTrip := cache.sets
+
+	b := &bypassRequestTransport{transport: rt, cache: cache}
+	if _, err := b.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if rt.calls != 1 {
+		t.Errorf("transport.calls = %d, want 1 (always re-fetch, even with a fresh entry)", rt.calls)
+	}
+	if cache.sets == setsBeforeRoundTrip {
+		t.Errorf("cache.sets = %d, want more than %d (response must still be stored)", cache.sets, setsBeforeRoundTrip)
+	}
+}
+
+func TestBypassRequestTransportRespectsNoStore(t *testing.T) {
+	rt := &fakeRoundTripper{}
+	cache := &countingCache{MemoryCache: NewMemoryCache()}
+	req := newTestGetRequest()
+	req.Header.Set("Cache-Control", "no-store")
+
+	b := &bypassRequestTransport{transport: rt, cache: cache}
+	if _, err := b.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if cache.sets != 0 {
+		t.Errorf("cache.sets = %d, want 0 when the request sends Cache-Control: no-store", cache.sets)
+	}
+}
+
+func TestBypassResponseTransportServesFreshHitWithoutRefetching(t *testing.T) {
+	rt := &fakeRoundTripper{}
+	cache := &countingCache{MemoryCache: NewMemoryCache()}
+	req := newTestGetRequest()
+
+	saveEntry(cache, cacheKey(req), &cachedEntry{
+		StatusCode:   200,
+		Header:       http.Header{"Cache-Control": {"max-age=60"}},
+		ResponseTime: time.Now(),
+	})
+	setsBeforeRoundTrip := cache.sets
+
+	b := &bypassResponseTransport{transport: rt, cache: cache}
+	resp, err := b.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if rt.calls != 0 {
+		t.Errorf("transport.calls = %d, want 0 (a fresh hit must not re-fetch)", rt.calls)
+	}
+	if resp.Header.Get("X-From-Cache") == "" {
+		t.Error("response is missing X-From-Cache, want the cached entry to be served")
+	}
+	if cache.sets != setsBeforeRoundTrip {
+		t.Errorf("cache.sets = %d, want %d (BypassResponseMode never stores)", cache.sets, setsBeforeRoundTrip)
+	}
+}
+
+func TestBypassResponseTransportRefetchesOnMissWithoutStoring(t *testing.T) {
+	rt := &fakeRoundTripper{}
+	cache := &countingCache{MemoryCache: NewMemoryCache()}
+	req := newTestGetRequest()
+
+	b := &bypassResponseTransport{transport: rt, cache: cache}
+	if _, err := b.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if rt.calls != 1 {
+		t.Errorf("transport.calls = %d, want 1 on a cache miss", rt.calls)
+	}
+	if cache.sets != 0 {
+		t.Errorf("cache.sets = %d, want 0 (BypassResponseMode never stores, even on a miss)", cache.sets)
+	}
+}
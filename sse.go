@@ -0,0 +1,77 @@
+package tafuta
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Event is a single message parsed from a text/event-stream body.
+type Event struct {
+	ID    string
+	Name  string
+	Data  string
+	Retry string
+}
+
+// EventStream iterates over the Server-Sent Events frames in a Response's
+// body.
+type EventStream struct {
+	scanner *bufio.Scanner
+	closer  io.Closer
+}
+
+// EventStream parses r's body as text/event-stream, returning an iterator
+// that decodes one frame at a time on top of the streaming reader - so
+// consuming SSE from wasm doesn't need the whole connection buffered up
+// front.
+func (r *Response) EventStream() *EventStream {
+	return &EventStream{scanner: bufio.NewScanner(r.Body), closer: r.Body}
+}
+
+// Next blocks until the next event arrives, returning false once the
+// stream has ended.
+func (s *EventStream) Next() (Event, bool) {
+	var e Event
+	var data []string
+	got := false
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if line == "" {
+			if got {
+				e.Data = strings.Join(data, "\n")
+				return e, true
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		got = true
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field = line[:i]
+			value = strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			e.Name = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			e.ID = value
+		case "retry":
+			e.Retry = value
+		}
+	}
+	// The stream ended without a terminating blank line, so whatever was
+	// accumulated in e/data is a partial event, not a dispatchable one -
+	// per the SSE spec an event is only complete once its blank line
+	// arrives. Discard it rather than returning a truncated Event.
+	return Event{}, false
+}
+
+// Close releases the underlying response body.
+func (s *EventStream) Close() error {
+	return s.closer.Close()
+}
@@ -0,0 +1,68 @@
+package tafuta
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func newTestEventStream(body string) *EventStream {
+	return &EventStream{
+		scanner: bufio.NewScanner(strings.NewReader(body)),
+		closer:  ioutil.NopCloser(strings.NewReader("")),
+	}
+}
+
+func TestEventStreamNext(t *testing.T) {
+	s := newTestEventStream("event: greeting\nid: 1\ndata: hello\ndata: world\n\ndata: second\n\n")
+
+	e, ok := s.Next()
+	if !ok {
+		t.Fatal("Next() ok = false, want true for the first event")
+	}
+	if e.Name != "greeting" || e.ID != "1" || e.Data != "hello\nworld" {
+		t.Errorf("Next() = %+v, want {Name: greeting, ID: 1, Data: hello\\nworld}", e)
+	}
+
+	e, ok = s.Next()
+	if !ok || e.Data != "second" {
+		t.Errorf("Next() = %+v, %v, want {Data: second}, true", e, ok)
+	}
+
+	if _, ok := s.Next(); ok {
+		t.Error("Next() ok = true, want false once the stream is exhausted")
+	}
+}
+
+func TestEventStreamNextIgnoresComments(t *testing.T) {
+	s := newTestEventStream(": this is a comment\ndata: hi\n\n")
+	e, ok := s.Next()
+	if !ok || e.Data != "hi" {
+		t.Errorf("Next() = %+v, %v, want {Data: hi}, true", e, ok)
+	}
+}
+
+func TestEventStreamNextDiscardsTrailingPartialEvent(t *testing.T) {
+	// The connection closes mid-message, with no terminating blank line.
+	// Per the SSE spec this event was never completed and must not be
+	// dispatched.
+	s := newTestEventStream("event: greeting\ndata: hello")
+
+	if e, ok := s.Next(); ok {
+		t.Errorf("Next() = %+v, true, want false for a stream ending without a blank line", e)
+	}
+}
+
+func TestEventStreamNextDiscardsTrailingPartialEventAfterCompleteOne(t *testing.T) {
+	s := newTestEventStream("data: complete\n\ndata: partial")
+
+	e, ok := s.Next()
+	if !ok || e.Data != "complete" {
+		t.Fatalf("Next() = %+v, %v, want {Data: complete}, true", e, ok)
+	}
+
+	if e, ok := s.Next(); ok {
+		t.Errorf("Next() = %+v, true, want false for the trailing partial event", e)
+	}
+}
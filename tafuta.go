@@ -24,9 +24,9 @@
 package tafuta
 
 import (
-	"fmt"
+	"context"
 	"io"
-	"io/ioutil"
+	"net/http"
 	"net/url"
 	"syscall/js"
 )
@@ -81,6 +81,18 @@ func (h *Header) Value() js.Value {
 	return h.value
 }
 
+// toHTTPHeader converts the wrapped Headers object into a net/http.Header,
+// for use by Transport.RoundTrip.
+func (h *Header) toHTTPHeader() http.Header {
+	out := make(http.Header)
+	it := &Iterator{h.value.Call("entries")}
+	it.Range(func(e js.Value) bool {
+		out.Add(e.Index(0).String(), e.Index(1).String())
+		return true
+	})
+	return out
+}
+
 // RequestCache defines modes for cache. This defines how the request will
 // interact with browser HTTP cache.
 //
@@ -352,7 +364,7 @@ type Iterator struct {
 }
 
 func (i *Iterator) Next() (done bool, value js.Value) {
-	v := i.Get("next")
+	v := i.Call("next")
 	done = v.Get("done").Bool()
 	value = v.Get("value")
 	return
@@ -389,6 +401,48 @@ type Request struct {
 	// sha256-BpfBw7ivV8q2jLiT13fxDYAe2tJllusRSZ273h2nFSE=).
 	Integrity string
 	Body      io.Reader
+
+	// Context, if set, is used to cancel the underlying fetch call (via
+	// an AbortController) and bounds how long Client.Do waits for a
+	// response. Defaults to context.Background(). Use DoContext instead
+	// of setting this directly when possible.
+	Context context.Context
+}
+
+// httpRequest converts r into a *http.Request, carrying the fetch-only
+// options (Cache, Credentials, Mode, Redirect, Referer, Integrity) on the
+// request's context so Transport.RoundTrip can recover them.
+func (r *Request) httpRequest() (*http.Request, error) {
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// A *FormData carries its own js.Value and must reach the Transport
+	// unread, so it travels over the context instead of as an io.Reader.
+	body := r.Body
+	formData, isFormData := body.(*FormData)
+	if isFormData {
+		body = nil
+	}
+	hreq, err := http.NewRequestWithContext(ctx, r.Method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	if r.Header != nil {
+		hreq.Header = r.Header.toHTTPHeader()
+	}
+	ctx = WithRequestOptions(hreq.Context(), RequestOptions{
+		Cache:       r.Cache,
+		Credentials: r.Credentials,
+		Mode:        r.Mode,
+		Redirect:    r.Redirect,
+		Referer:     r.Referer,
+		Integrity:   r.Integrity,
+	})
+	if isFormData {
+		ctx = WithFormData(ctx, formData)
+	}
+	return hreq.WithContext(ctx), nil
 }
 
 type ResponseType uint
@@ -422,7 +476,9 @@ func (r ResponseType) String() string {
 	return respTypMap[r]
 }
 
-// Response represents a response to a fetch API Request.
+// Response represents a response to a fetch API Request. Body streams
+// straight from the underlying fetch Response; read it with io.ReadAll(res.Body)
+// or, for a text/event-stream body, res.EventStream().
 type Response struct {
 	Headers    *Header
 	Ok         bool
@@ -432,7 +488,6 @@ type Response struct {
 	Type       ResponseType
 	URL        *url.URL
 	Body       io.ReadCloser
-	value      js.Value
 }
 
 // NewResponse creates *Response struct from Response js object.
@@ -449,94 +504,108 @@ func NewResponse(v js.Value) (*Response, error) {
 		return nil, err
 	}
 	res.URL = u
-	res.value = v
 	return res, nil
 }
 
-// Text returns Response body contents as a string. This is a blocking call,
-// please use this in a separate goroutines to avoid blocking execution of other
-// code.
-func (r *Response) Text() (res string) {
-	done := make(chan struct{})
-	responseCallback := js.NewCallback(func(v []js.Value) {
-		res = v[0].String()
-		done <- struct{}{}
-	})
-	defer responseCallback.Release()
-	r.value.Call("text").Call("then", responseCallback)
-	<-done
-	return
-}
-
+// Client is a convenience wrapper around a Transport for the common case of
+// issuing a single request and getting back tafuta's wasm-flavoured
+// Request/Response instead of net/http's. Anything that needs the rest of
+// the net/http ecosystem should use the Transport directly with a
+// *http.Client instead.
 type Client struct {
-	value js.Value
+	Transport *Transport
+
+	// Cache, if set, layers a RFC 7234 cache on top of Transport for this
+	// Client's requests, with per-request behavior chosen by its
+	// Matcher. Leave nil to talk to the network directly.
+	Cache *PolicyCache
 }
 
-func NewClient() *Client {
-	return &Client{value: FetchValue()}
+// NewClient returns a Client whose Transport is configured with opts.
+func NewClient(opts ...TransportOption) *Client {
+	return &Client{Transport: NewTransport(opts...)}
 }
 
-// Do sends request using fetch AP. This method is blocking, to avoid
-// deadlocking your app please call this inside a goroutine.
-func (c *Client) Do(req *Request) (res *Response, err error) {
-	var resources resourceList
-	defer func() {
-		if resources != nil {
-			resources.free()
-		}
-		if v := recover(); v != nil {
-			err = fmt.Errorf("%v", v)
-		}
-	}()
-	args := []interface{}{req.URL}
-	opts := make(map[string]interface{})
-	if req.Method != "" {
-		opts["method"] = req.Method
-	}
-	if req.Header != nil {
-		opts["headers"] = req.Header.Value()
-	}
-	if mode := req.Mode.String(); mode != "" {
-		opts["mode"] = mode
+// Do sends req using the Client's Transport, routed through Cache when
+// set. This method is blocking, to avoid deadlocking your app please call
+// this inside a goroutine.
+func (c *Client) Do(req *Request) (*Response, error) {
+	hreq, err := req.httpRequest()
+	if err != nil {
+		return nil, err
 	}
-	if creds := req.Credentials.String(); creds != "" {
-		opts["credentials"] = creds
+	hres, err := c.roundTripper(req).RoundTrip(hreq)
+	if err != nil {
+		return nil, err
 	}
-	if cache := req.Cache.String(); cache != "" {
-		opts["cache"] = cache
+	return responseFromHTTP(hres), nil
+}
+
+func (c *Client) roundTripper(req *Request) http.RoundTripper {
+	if c.Cache == nil {
+		return c.Transport
 	}
-	if redirect := req.Redirect.String(); redirect != "" {
-		opts["redirect"] = redirect
+	switch c.Cache.mode(req) {
+	case BypassMode:
+		return c.Transport
+	case BypassRequestMode:
+		return &bypassRequestTransport{transport: c.Transport, cache: c.Cache.Cache}
+	case BypassResponseMode:
+		return &bypassResponseTransport{transport: c.Transport, cache: c.Cache.Cache}
+	default:
+		return NewCacheTransport(c.Transport, c.Cache.Cache)
 	}
-	if req.Referer != "" {
-		opts["referrer"] = req.Referer
+}
+
+// DoContext is like Do, but ctx bounds and can cancel the request instead
+// of req.Context.
+func (c *Client) DoContext(ctx context.Context, req *Request) (*Response, error) {
+	r := *req
+	r.Context = ctx
+	return c.Do(&r)
+}
+
+// responseFromHTTP builds a *Response from a *http.Response, which may have
+// come from any http.RoundTripper - a Transport, a CacheTransport, one of
+// the bypass* transports, or a caller's own retry/logging wrapper around
+// any of those. The fields net/http itself models (Headers, Ok, Status,
+// StatusText, Body) are always derived from hres, so wrapping
+// RoundTrippers that replace the Body stay correct. Redirected, Type and
+// the final URL are only recovered when hres.Body still carries the
+// underlying fetch Response value; otherwise Redirected and Type keep their
+// zero value and URL is hres.Request's URL.
+func responseFromHTTP(hres *http.Response) *Response {
+	res := &Response{
+		Headers:    headerFromHTTP(hres.Header),
+		Ok:         hres.StatusCode >= 200 && hres.StatusCode < 300,
+		Status:     hres.StatusCode,
+		StatusText: http.StatusText(hres.StatusCode),
+		Body:       hres.Body,
 	}
-	if req.Integrity != "" {
-		opts["integrity"] = req.Integrity
+	if hres.Request != nil {
+		res.URL = hres.Request.URL
 	}
-	done := make(chan struct{})
-	if req.Body != nil {
-		b, err := ioutil.ReadAll(req.Body)
-		if err != nil {
-			return nil, err
+	if b, ok := hres.Body.(*body); ok {
+		res.Redirected = b.raw.Get("redirected").Bool()
+		res.Type = reverseRespTypMap[b.raw.Get("type").String()]
+		if u, err := url.Parse(b.raw.Get("url").String()); err == nil {
+			res.URL = u
 		}
-		a := js.TypedArrayOf(b)
-		resources = append(resources, a)
-		opts["body"] = a
 	}
-	if len(opts) > 0 {
-		args = append(args, opts)
+	return res
+}
+
+// headerFromHTTP builds a *Header (a wasm Headers object) populated from a
+// stdlib http.Header, for responses that must expose Headers even when
+// they didn't come straight from a Transport's fetch call.
+func headerFromHTTP(h http.Header) *Header {
+	out := NewHeader()
+	for k, vs := range h {
+		for _, v := range vs {
+			out.Add(k, v)
+		}
 	}
-	request := js.Global().Get("Request").New(args...)
-	responseCallback := js.NewCallback(func(v []js.Value) {
-		res, err = NewResponse(v[0])
-		done <- struct{}{}
-	})
-	r := c.value.Invoke(request)
-	resources = append(resources, responseCallback)
-	r.Call("then", responseCallback)
-	<-done
-	return
+	return out
 }
 
 type resource interface {
@@ -0,0 +1,328 @@
+package tafuta
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"syscall/js"
+)
+
+// TransportOption configures the fetch-only defaults of a Transport, i.e.
+// the options net/http.Request has no field for: Cache, Credentials, Mode,
+// Redirect, Referer and Integrity. Per-request overrides are possible via
+// WithRequestOptions.
+type TransportOption interface {
+	set(*Transport)
+}
+
+type transportOption func(*Transport)
+
+func (f transportOption) set(t *Transport) { f(t) }
+
+// WithCache sets the default RequestCache used for requests that don't
+// carry their own RequestOptions.
+func WithCache(c RequestCache) TransportOption {
+	return transportOption(func(t *Transport) { t.cache = c })
+}
+
+// WithCredentials sets the default RequestCredentials.
+func WithCredentials(c RequestCredentials) TransportOption {
+	return transportOption(func(t *Transport) { t.credentials = c })
+}
+
+// WithMode sets the default RequestMode.
+func WithMode(m RequestMode) TransportOption {
+	return transportOption(func(t *Transport) { t.mode = m })
+}
+
+// WithRedirect sets the default RequestRedirect.
+func WithRedirect(r RequestRedirect) TransportOption {
+	return transportOption(func(t *Transport) { t.redirect = r })
+}
+
+// WithReferer sets the default referrer.
+func WithReferer(referer string) TransportOption {
+	return transportOption(func(t *Transport) { t.referer = referer })
+}
+
+// WithIntegrity sets the default subresource integrity value.
+func WithIntegrity(integrity string) TransportOption {
+	return transportOption(func(t *Transport) { t.integrity = integrity })
+}
+
+// RequestOptions carries the fetch-only options that net/http.Request has
+// no field for. Attach a value to a request's context with
+// WithRequestOptions to override a Transport's defaults for that single
+// request.
+type RequestOptions struct {
+	Cache       RequestCache
+	Credentials RequestCredentials
+	Mode        RequestMode
+	Redirect    RequestRedirect
+	Referer     string
+	Integrity   string
+}
+
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a copy of ctx carrying o, so that a Transport's
+// RoundTrip picks it up in place of its own defaults. Use together with
+// http.NewRequestWithContext.
+func WithRequestOptions(ctx context.Context, o RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, o)
+}
+
+// Transport implements http.RoundTripper on top of the browser fetch API.
+// This lets a *http.Client backed by a Transport reuse the rest of the
+// net/http ecosystem (retry middleware, OAuth transports, tracing
+// wrappers, ...) from wasm.
+//
+// 	client := &http.Client{Transport: tafuta.NewTransport()}
+// 	res, err := client.Get("https://example.com")
+//
+type Transport struct {
+	cache       RequestCache
+	credentials RequestCredentials
+	mode        RequestMode
+	redirect    RequestRedirect
+	referer     string
+	integrity   string
+}
+
+// NewTransport returns a Transport configured with opts.
+func NewTransport(opts ...TransportOption) *Transport {
+	t := &Transport{}
+	for _, o := range opts {
+		o.set(t)
+	}
+	return t
+}
+
+func (t *Transport) options(ctx context.Context) RequestOptions {
+	o := RequestOptions{
+		Cache:       t.cache,
+		Credentials: t.credentials,
+		Mode:        t.mode,
+		Redirect:    t.redirect,
+		Referer:     t.referer,
+		Integrity:   t.integrity,
+	}
+	if v, ok := ctx.Value(requestOptionsKey{}).(RequestOptions); ok {
+		if v.Cache != 0 {
+			o.Cache = v.Cache
+		}
+		if v.Credentials != 0 {
+			o.Credentials = v.Credentials
+		}
+		if v.Mode != 0 {
+			o.Mode = v.Mode
+		}
+		if v.Redirect != 0 {
+			o.Redirect = v.Redirect
+		}
+		if v.Referer != "" {
+			o.Referer = v.Referer
+		}
+		if v.Integrity != "" {
+			o.Integrity = v.Integrity
+		}
+	}
+	return o
+}
+
+// RoundTrip implements http.RoundTripper, translating req into a fetch call
+// and turning the resulting fetch Response into a *http.Response.
+func (t *Transport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	var resources resourceList
+	defer func() {
+		if resources != nil {
+			resources.free()
+		}
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%v", v)
+		}
+	}()
+	ctx := req.Context()
+	opt := t.options(ctx)
+	args := []interface{}{req.URL.String()}
+	fopts := make(map[string]interface{})
+	if req.Method != "" {
+		fopts["method"] = req.Method
+	}
+	if len(req.Header) > 0 {
+		fopts["headers"] = headerFromHTTP(req.Header).Value()
+	}
+	if mode := opt.Mode.String(); mode != "" {
+		fopts["mode"] = mode
+	}
+	if creds := opt.Credentials.String(); creds != "" {
+		fopts["credentials"] = creds
+	}
+	if cache := opt.Cache.String(); cache != "" {
+		fopts["cache"] = cache
+	}
+	if redirect := opt.Redirect.String(); redirect != "" {
+		fopts["redirect"] = redirect
+	}
+	if opt.Referer != "" {
+		fopts["referrer"] = opt.Referer
+	}
+	if opt.Integrity != "" {
+		fopts["integrity"] = opt.Integrity
+	}
+	if fd, ok := formDataFromContext(ctx); ok {
+		fopts["body"] = fd.Value()
+	} else if req.Body != nil {
+		b, rerr := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if rerr != nil {
+			return nil, rerr
+		}
+		a := js.TypedArrayOf(b)
+		resources = append(resources, a)
+		fopts["body"] = a
+	}
+	controller := js.Global().Get("AbortController").New()
+	fopts["signal"] = controller.Get("signal")
+	if len(fopts) > 0 {
+		args = append(args, fopts)
+	}
+	jsReq := js.Global().Get("Request").New(args...)
+
+	abortDone := make(chan struct{})
+	if ctxDone := ctx.Done(); ctxDone != nil {
+		go func() {
+			select {
+			case <-ctxDone:
+				controller.Call("abort")
+			case <-abortDone:
+			}
+		}()
+		defer close(abortDone)
+	}
+
+	done := make(chan struct{})
+	var raw js.Value
+	responseCallback := js.NewCallback(func(v []js.Value) {
+		raw = v[0]
+		done <- struct{}{}
+	})
+	resources = append(resources, responseCallback)
+	var fetchErr error
+	rejectCallback := js.NewCallback(func(v []js.Value) {
+		if cerr := ctx.Err(); cerr != nil {
+			fetchErr = cerr
+		} else {
+			fetchErr = fmt.Errorf("tafuta: fetch: %s", v[0].Get("message").String())
+		}
+		done <- struct{}{}
+	})
+	resources = append(resources, rejectCallback)
+	promise := FetchValue().Invoke(jsReq)
+	promise.Call("then", responseCallback)
+	promise.Call("catch", rejectCallback)
+	<-done
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	code := raw.Get("status").Int()
+	header := headersFromJS(raw.Get("headers"))
+	cl := int64(-1)
+	if v := header.Get("Content-Length"); v != "" {
+		if n, perr := strconv.ParseInt(v, 10, 64); perr == nil {
+			cl = n
+		}
+	}
+	res = &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, raw.Get("statusText").String()),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          newBody(raw),
+		ContentLength: cl,
+		Request:       req,
+	}
+	return res, nil
+}
+
+func headersFromJS(v js.Value) http.Header {
+	h := make(http.Header)
+	it := &Iterator{v.Call("entries")}
+	it.Range(func(e js.Value) bool {
+		h.Add(e.Index(0).String(), e.Index(1).String())
+		return true
+	})
+	return h
+}
+
+// body is the io.ReadCloser used for http.Response.Body. It streams chunks
+// straight off the fetch Response's ReadableStream via reader.read(),
+// rather than buffering the whole response up front, which is what makes
+// large downloads, SSE and chunked responses usable. It also retains the
+// underlying fetch Response value so Client.Do can recover wasm-specific
+// metadata (Redirected, Type, final URL) that net/http has no field for.
+type body struct {
+	raw    js.Value
+	reader js.Value
+	buf    []byte
+	eof    bool
+}
+
+func newBody(raw js.Value) *body {
+	return &body{raw: raw, reader: raw.Get("body").Call("getReader")}
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	for len(b.buf) == 0 {
+		if b.eof {
+			return 0, io.EOF
+		}
+		chunk, eof, err := b.next()
+		if err != nil {
+			return 0, err
+		}
+		b.eof = eof
+		b.buf = chunk
+	}
+	n := copy(p, b.buf)
+	b.buf = b.buf[n:]
+	return n, nil
+}
+
+// next blocks on the promise returned by reader.read(), copying the
+// resulting Uint8Array chunk into a Go byte slice.
+func (b *body) next() (chunk []byte, eof bool, err error) {
+	done := make(chan struct{})
+	success := js.NewCallback(func(v []js.Value) {
+		eof = v[0].Get("done").Bool()
+		if !eof {
+			chunk = toBytes(v[0].Get("value"))
+		}
+		done <- struct{}{}
+	})
+	defer success.Release()
+	failure := js.NewCallback(func(v []js.Value) {
+		err = fmt.Errorf("tafuta: reading response body: %v", v[0])
+		done <- struct{}{}
+	})
+	defer failure.Release()
+	p := b.reader.Call("read")
+	p.Call("then", success)
+	p.Call("catch", failure)
+	<-done
+	return
+}
+
+// Close cancels the underlying stream reader. The callbacks used for each
+// read are already released by next once that read completes.
+func (b *body) Close() error {
+	b.reader.Call("cancel")
+	b.eof = true
+	b.buf = nil
+	return nil
+}